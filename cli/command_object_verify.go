@@ -2,12 +2,15 @@ package cli
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
-	"io/ioutil"
 	"math/rand"
 	"os"
-	"sync"
+	"os/signal"
+	"sync/atomic"
 	"time"
 
 	"github.com/kopia/kopia/block"
@@ -16,77 +19,221 @@ import (
 	"github.com/kopia/kopia/object"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/verify"
 )
 
+// reportFormats lists the values accepted by --report-format.
+var reportFormats = []string{string(verify.ReportFormatJSON), string(verify.ReportFormatNDJSON)}
+
+// exitCodeVerifyInterrupted is the exit code carried by errVerifyInterrupted, the error a
+// verify or scrub run returns when it is aborted before completion because its context was
+// cancelled (e.g. SIGINT).
+const exitCodeVerifyInterrupted = 3
+
+// ExitCoder is implemented by an error that carries its own process exit code. Nothing in
+// package cli inspects it when an Action returns, so a command returning such an error
+// exits with the same generic failure status as one returning a plain error.
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+// exitCodeError pairs an error with the process exit code it should cause.
+type exitCodeError struct {
+	error
+	code int
+}
+
+func (e *exitCodeError) ExitCode() int { return e.code }
+
+// errVerifyInterrupted is returned by runVerifyCommand/runBlockScrubCommand instead of
+// calling os.Exit directly, so that this function's own deferred cleanup (cancel(),
+// signal.Stop(sigCh), reportFile.Close()) still runs, and so that verify/scrub can be
+// driven in-process (e.g. by the server-side background verifier object.VerifyScheduler's
+// doc comment anticipates) without an interrupted run tearing down the whole host process.
+var errVerifyInterrupted = &exitCodeError{error: errors.New("verify run was interrupted before completion"), code: exitCodeVerifyInterrupted}
+
 var (
-	verifyCommand               = objectCommands.Command("verify", "Verify the contents of stored object")
-	verifyCommandErrorThreshold = verifyCommand.Flag("max-errors", "Maximum number of errors before stopping").Default("0").Int()
-	verifyCommandDirObjectIDs   = verifyCommand.Flag("directory-id", "Directory object IDs to verify").Strings()
-	verifyCommandFileObjectIDs  = verifyCommand.Flag("file-id", "File object IDs to verify").Strings()
-	verifyCommandAllSources     = verifyCommand.Flag("all-sources", "Verify all snapshots").Bool()
-	verifyCommandSources        = verifyCommand.Flag("sources", "Verify the provided sources").Strings()
-	verifyCommandParallel       = verifyCommand.Flag("parallel", "Parallelization").Default("16").Int()
-	verifyCommandFilesPercent   = verifyCommand.Flag("verify-files-percent", "Randomly verify a percentage of files").Default("0").Int()
+	verifyCommand                    = objectCommands.Command("verify", "Verify the contents of stored object")
+	verifyCommandErrorThreshold      = verifyCommand.Flag("max-errors", "Maximum number of errors before stopping").Envar("KOPIA_VERIFY_MAX_ERRORS").Default("0").IsSetByUser(&verifyCommandErrorThresholdSet).Int()
+	verifyCommandDirObjectIDs        = verifyCommand.Flag("directory-id", "Directory object IDs to verify").Strings()
+	verifyCommandFileObjectIDs       = verifyCommand.Flag("file-id", "File object IDs to verify").Strings()
+	verifyCommandAllSources          = verifyCommand.Flag("all-sources", "Verify all snapshots").Envar("KOPIA_VERIFY_ALL_SOURCES").Bool()
+	verifyCommandSources             = verifyCommand.Flag("sources", "Verify the provided sources").Envar("KOPIA_VERIFY_SOURCES").Strings()
+	verifyCommandParallel            = verifyCommand.Flag("parallel", "Parallelization").Envar("KOPIA_VERIFY_PARALLEL").Default("16").IsSetByUser(&verifyCommandParallelSet).Int()
+	verifyCommandFilesPercent        = verifyCommand.Flag("verify-files-percent", "Randomly verify a percentage of files").Envar("KOPIA_VERIFY_FILES_PERCENT").Default("0").IsSetByUser(&verifyCommandFilesPercentSet).Int()
+	verifyCommandMaxRetries          = verifyCommand.Flag("max-retries", "Maximum number of times to retry a transient error before giving up").Envar("KOPIA_VERIFY_MAX_RETRIES").Default("5").IsSetByUser(&verifyCommandMaxRetriesSet).Int()
+	verifyCommandRetryInitialBackoff = verifyCommand.Flag("retry-initial-backoff", "Initial backoff delay applied after a transient error").Envar("KOPIA_VERIFY_RETRY_INITIAL_BACKOFF").Default("1s").IsSetByUser(&verifyCommandRetryInitialBackoffSet).Duration()
+	verifyCommandRetryMaxBackoff     = verifyCommand.Flag("retry-max-backoff", "Maximum backoff delay between retries").Envar("KOPIA_VERIFY_RETRY_MAX_BACKOFF").Default("30s").IsSetByUser(&verifyCommandRetryMaxBackoffSet).Duration()
+	verifyCommandReportFile          = verifyCommand.Flag("report-file", "Write a machine-readable verify report to the given file").String()
+	verifyCommandReportFormat        = verifyCommand.Flag("report-format", "Format of --report-file").Default(string(verify.ReportFormatJSON)).Enum(reportFormats...)
+	verifyCommandFromReport          = verifyCommand.Flag("from-report", "Only re-verify the objects that failed in a previous --report-file").String()
+
+	// set via each flag's IsSetByUser above, so applyVerifyPolicy can tell an explicit
+	// --flag or KOPIA_VERIFY_* environment variable apart from a value that's merely at
+	// its hardcoded default and so still open to being filled in by policy.
+	verifyCommandErrorThresholdSet      bool
+	verifyCommandParallelSet            bool
+	verifyCommandFilesPercentSet        bool
+	verifyCommandMaxRetriesSet          bool
+	verifyCommandRetryInitialBackoffSet bool
+	verifyCommandRetryMaxBackoffSet     bool
 )
 
-type verifier struct {
-	mgr       *snapshot.Manager
-	om        *object.Manager
-	workQueue *parallelwork.Queue
-	startTime time.Time
+// verifyOptions bundles the knobs that control a single verify run, resolved from
+// (in increasing precedence) a source's persisted policy.VerifyPolicy, the
+// KOPIA_VERIFY_* environment variables, and explicit command-line flags.
+type verifyOptions struct {
+	parallel       int
+	filesPercent   int
+	errorThreshold int
+	retryPolicy    verify.RetryPolicy
+}
 
-	mu   sync.Mutex
-	seen map[object.ID]bool
+// flagVerifyOptions returns the verifyOptions implied by the current flags (which already
+// reflect any KOPIA_VERIFY_* environment variable fallback via kingpin's Envar()).
+func flagVerifyOptions() verifyOptions {
+	return verifyOptions{
+		parallel:       *verifyCommandParallel,
+		filesPercent:   *verifyCommandFilesPercent,
+		errorThreshold: *verifyCommandErrorThreshold,
+		retryPolicy: verify.RetryPolicy{
+			MaxRetries:     *verifyCommandMaxRetries,
+			InitialBackoff: *verifyCommandRetryInitialBackoff,
+			MaxBackoff:     *verifyCommandRetryMaxBackoff,
+		},
+	}
+}
 
-	errors []error
+// verifyOptionsSetByUser parallels verifyOptions, recording which of its fields were set by
+// an explicit --flag or KOPIA_VERIFY_* environment variable, as opposed to being left at
+// their hardcoded default. applyVerifyPolicy uses this to decide which fields are still open
+// to being filled in by policy.
+type verifyOptionsSetByUser struct {
+	parallel       bool
+	filesPercent   bool
+	errorThreshold bool
+	maxRetries     bool
+	initialBackoff bool
+	maxBackoff     bool
 }
 
-func (v *verifier) progressCallback(enqueued, active, completed int64) {
-	elapsed := time.Since(v.startTime)
-	maybeTimeRemaining := ""
-	if elapsed > 1*time.Second && enqueued > 0 && completed > 0 {
-		completedRatio := float64(completed) / float64(enqueued)
-		predictedSeconds := elapsed.Seconds() / completedRatio
-		predictedEndTime := v.startTime.Add(time.Duration(predictedSeconds) * time.Second)
-
-		dt := time.Until(predictedEndTime)
-		if dt > 0 {
-			maybeTimeRemaining = fmt.Sprintf(" remaining %v (ETA %v)", dt.Truncate(1*time.Second), predictedEndTime.Truncate(1*time.Second).Format(timeFormat))
-		}
+// flagVerifyOptionsSetByUser reports which verifyOptions fields flagVerifyOptions resolved
+// from an explicit flag/environment variable rather than its hardcoded default.
+func flagVerifyOptionsSetByUser() verifyOptionsSetByUser {
+	return verifyOptionsSetByUser{
+		parallel:       verifyCommandParallelSet,
+		filesPercent:   verifyCommandFilesPercentSet,
+		errorThreshold: verifyCommandErrorThresholdSet,
+		maxRetries:     verifyCommandMaxRetriesSet,
+		initialBackoff: verifyCommandRetryInitialBackoffSet,
+		maxBackoff:     verifyCommandRetryMaxBackoffSet,
 	}
-	fmt.Fprintf(os.Stderr, "Found %v objects, verifying %v, completed %v objects%v.\n", enqueued, active, completed, maybeTimeRemaining)
 }
 
-func (v *verifier) tooManyErrors() bool {
-	v.mu.Lock()
-	defer v.mu.Unlock()
+type verifier struct {
+	mgr          *snapshot.Manager
+	om           *object.Manager
+	workQueue    *parallelwork.Queue
+	startTime    time.Time
+	retryPolicy  verify.RetryPolicy
+	reportWriter *verify.ReportWriter
+	filesPercent int
+	errSink      *verify.ErrorSink
+	progress     *verify.ProgressReporter
+	scheduler    *object.VerifyScheduler
+
+	// counters, safe for concurrent use.
+	numVerified int64
+	numSkipped  int64
+	numRetried  int64
+	numFailed   int64
+}
+
+// cancelled returns true if ctx has already been cancelled, in which case any
+// in-progress work item should stop without reporting an error of its own.
+func cancelled(ctx context.Context) bool {
+	return ctx.Err() != nil
+}
 
-	if *verifyCommandErrorThreshold == 0 {
-		return false
+// newVerifier creates a verifier configured from opts.
+func newVerifier(mgr *snapshot.Manager, om *object.Manager, opts verifyOptions) *verifier {
+	startTime := time.Now()
+
+	return &verifier{
+		mgr:          mgr,
+		om:           om,
+		startTime:    startTime,
+		workQueue:    parallelwork.NewQueue(),
+		scheduler:    object.NewVerifyScheduler(0),
+		retryPolicy:  opts.retryPolicy,
+		filesPercent: opts.filesPercent,
+		errSink:      &verify.ErrorSink{Threshold: opts.errorThreshold},
+		progress:     &verify.ProgressReporter{Noun: "objects", TimeFormat: timeFormat, StartTime: startTime},
 	}
+}
 
-	return len(v.errors) >= *verifyCommandErrorThreshold
+func (v *verifier) progressCallback(enqueued, active, completed int64) {
+	v.progress.Callback(enqueued, active, completed)
 }
 
-func (v *verifier) reportError(path string, err error) bool {
-	v.mu.Lock()
-	defer v.mu.Unlock()
+func (v *verifier) tooManyErrors() bool {
+	return v.errSink.TooMany()
+}
+
+func (v *verifier) reportError(oid object.ID, path string, expectedLength int64, err error, retries int) bool {
+	atomic.AddInt64(&v.numFailed, 1)
+
+	if v.reportWriter != nil {
+		v.reportWriter.Record(verify.ObjectResult{
+			ObjectID:       oid.String(),
+			Path:           path,
+			ExpectedLength: expectedLength,
+			ErrorClass:     errorClassName(verify.ClassifyError(err)),
+			Error:          err.Error(),
+			Retries:        retries,
+		})
+	}
 
 	log.Warningf("failed on %v: %v", path, err)
-	v.errors = append(v.errors, err)
-	return len(v.errors) >= *verifyCommandErrorThreshold
+
+	return v.errSink.Add(err)
 }
 
-func (v *verifier) shouldEnqueue(oid object.ID) bool {
-	v.mu.Lock()
-	defer v.mu.Unlock()
+// errorClassName returns the human-readable name of an error class, used in reports.
+func errorClassName(c verify.ErrorClass) string {
+	if c == verify.ErrorClassTransient {
+		return "transient"
+	}
 
-	if v.seen[oid] {
-		return false
+	return "permanent"
+}
+
+// recordVerified records a successfully verified object in the report, if one is active.
+// contentHash is the hex-encoded SHA-256 of the object's contents as observed during
+// readEntireObject, or empty if --verify-files-percent didn't select this object for a
+// full read.
+func (v *verifier) recordVerified(oid object.ID, path string, expectedLength, actualLength int64, contentHash string, retries int) {
+	if v.reportWriter == nil {
+		return
 	}
 
-	v.seen[oid] = true
-	return true
+	v.reportWriter.Record(verify.ObjectResult{
+		ObjectID:       oid.String(),
+		Path:           path,
+		ExpectedLength: expectedLength,
+		ActualLength:   actualLength,
+		ContentHash:    contentHash,
+		Retries:        retries,
+	})
+}
+
+// shouldEnqueue reports whether oid has not already been claimed by this verifier (via the
+// scheduler) and, if so, claims it: the caller is now responsible for eventually reporting
+// the result via v.scheduler.Complete.
+func (v *verifier) shouldEnqueue(oid object.ID) bool {
+	_, isNew := v.scheduler.Schedule(oid)
+	return isNew
 }
 
 func (v *verifier) enqueueVerifyDirectory(ctx context.Context, oid object.ID, path string) {
@@ -94,7 +241,14 @@ func (v *verifier) enqueueVerifyDirectory(ctx context.Context, oid object.ID, pa
 	if !v.shouldEnqueue(oid) {
 		return
 	}
-	v.workQueue.EnqueueFront(func() {
+	v.enqueueVerifyDirectoryUnchecked(ctx, oid, path)
+}
+
+// enqueueVerifyDirectoryUnchecked enqueues oid without consulting/claiming it via
+// v.scheduler; used when restoring pending work from a checkpoint, where oid is already
+// known to have been seen.
+func (v *verifier) enqueueVerifyDirectoryUnchecked(ctx context.Context, oid object.ID, path string) {
+	v.workQueue.EnqueueFront(workItem{kind: workItemDirectory, oid: oid, path: path, expectedLength: -1}, func() {
 		v.doVerifyDirectory(ctx, oid, path)
 	})
 }
@@ -104,23 +258,59 @@ func (v *verifier) enqueueVerifyObject(ctx context.Context, oid object.ID, path
 	if !v.shouldEnqueue(oid) {
 		return
 	}
-	v.workQueue.EnqueueBack(func() {
+	v.enqueueVerifyObjectUnchecked(ctx, oid, path, expectedLength)
+}
+
+// enqueueVerifyObjectUnchecked enqueues oid without consulting/claiming it via v.scheduler;
+// used when restoring pending work from a checkpoint, where oid is already known to have
+// been seen.
+func (v *verifier) enqueueVerifyObjectUnchecked(ctx context.Context, oid object.ID, path string, expectedLength int64) {
+	v.workQueue.EnqueueBack(workItem{kind: workItemObject, oid: oid, path: path, expectedLength: expectedLength}, func() {
 		v.doVerifyObject(ctx, oid, path, expectedLength)
 	})
 }
 
 func (v *verifier) doVerifyDirectory(ctx context.Context, oid object.ID, path string) {
+	// Bail out before claiming oid as Complete: a cancelled run hasn't actually verified
+	// anything, and reporting a zero-value (so Err==nil, "success") result here would make
+	// a checkpoint or another caller sharing the scheduler treat oid as verified when it
+	// wasn't.
+	if cancelled(ctx) {
+		return
+	}
+
+	var result object.VerifyResult
+	defer func() { v.scheduler.Complete(oid, result) }()
+
 	log.Debugf("verifying directory %q (%v)", path, oid)
 
-	d := v.mgr.DirectoryEntry(oid, nil)
-	entries, err := d.Readdir(ctx)
+	var entries fs.Entries
+
+	retries, err := v.retryPolicy.Do(ctx, func() error {
+		d := v.mgr.DirectoryEntry(oid, nil)
+		e, err := d.Readdir(ctx)
+		entries = e
+		return err
+	})
+	if retries > 0 {
+		atomic.AddInt64(&v.numRetried, 1)
+	}
+
 	if err != nil {
-		v.reportError(path, fmt.Errorf("error reading %v: %v", oid, err))
+		result.Err = err
+		if !cancelled(ctx) {
+			v.reportError(oid, path, -1, fmt.Errorf("error reading %v: %v", oid, err), retries)
+		}
 		return
 	}
 
-	for _, e := range entries {
-		if v.tooManyErrors() {
+	for i, e := range entries {
+		if v.tooManyErrors() || cancelled(ctx) {
+			skipped := int64(len(entries) - i)
+			atomic.AddInt64(&v.numSkipped, skipped)
+			if v.reportWriter != nil {
+				v.reportWriter.RecordSkipped(skipped)
+			}
 			break
 		}
 
@@ -136,6 +326,15 @@ func (v *verifier) doVerifyDirectory(ctx context.Context, oid object.ID, path st
 }
 
 func (v *verifier) doVerifyObject(ctx context.Context, oid object.ID, path string, expectedLength int64) {
+	// See the equivalent check in doVerifyDirectory: don't report oid Complete for work
+	// that never ran.
+	if cancelled(ctx) {
+		return
+	}
+
+	var result object.VerifyResult
+	defer func() { v.scheduler.Complete(oid, result) }()
+
 	if expectedLength < 0 {
 		log.Debugf("verifying object %v", oid)
 	} else {
@@ -143,65 +342,178 @@ func (v *verifier) doVerifyObject(ctx context.Context, oid object.ID, path strin
 	}
 
 	var length int64
-	var err error
 
-	length, _, err = v.om.VerifyObject(ctx, oid)
+	retries, err := v.retryPolicy.Do(ctx, func() error {
+		l, _, verr := v.om.VerifyObject(ctx, oid)
+		length = l
+		return verr
+	})
+	if retries > 0 {
+		atomic.AddInt64(&v.numRetried, 1)
+	}
+
+	result.Length = length
+
 	if err != nil {
-		v.reportError(path, fmt.Errorf("error verifying %v: %v", oid, err))
+		result.Err = err
+		if !cancelled(ctx) {
+			v.reportError(oid, path, expectedLength, fmt.Errorf("error verifying %v: %v", oid, err), retries)
+		}
+		return
 	}
 
 	if expectedLength >= 0 && length != expectedLength {
-		v.reportError(path, fmt.Errorf("invalid object length %q, %v, expected %v", oid, length, expectedLength))
+		result.Err = fmt.Errorf("invalid object length %q, %v, expected %v", oid, length, expectedLength)
+		v.reportError(oid, path, expectedLength, result.Err, retries)
+		return
 	}
 
-	if rand.Intn(100) < *verifyCommandFilesPercent {
-		if err := v.readEntireObject(ctx, oid, path); err != nil {
-			v.reportError(path, fmt.Errorf("error reading object %v: %v", oid, err))
+	var contentHash string
+
+	if rand.Intn(100) < v.filesPercent {
+		h, err := v.readEntireObject(ctx, oid, path)
+		if err != nil {
+			result.Err = err
+			if !cancelled(ctx) {
+				v.reportError(oid, path, expectedLength, fmt.Errorf("error reading object %v: %v", oid, err), retries)
+			}
+			return
 		}
+		contentHash = h
 	}
+
+	atomic.AddInt64(&v.numVerified, 1)
+	v.recordVerified(oid, path, expectedLength, length, contentHash, retries)
 }
 
-func (v *verifier) readEntireObject(ctx context.Context, oid object.ID, path string) error {
+// readEntireObject reads oid's full contents and returns their hex-encoded SHA-256, so that
+// --verify-files-percent catches bit-rot a length/index check alone would miss.
+func (v *verifier) readEntireObject(ctx context.Context, oid object.ID, path string) (string, error) {
 	log.Debugf("reading object %v %v", oid, path)
 	ctx = block.UsingBlockCache(ctx, false)
 
-	// also read the entire file
-	r, err := v.om.Open(ctx, oid)
-	if err != nil {
+	var h hash.Hash
+
+	_, err := v.retryPolicy.Do(ctx, func() error {
+		r, err := v.om.Open(ctx, oid)
+		if err != nil {
+			return err
+		}
+		defer r.Close() //nolint:errcheck
+
+		// also read the entire file
+		h = sha256.New()
+		_, err = io.Copy(h, r)
 		return err
+	})
+	if err != nil {
+		return "", err
 	}
-	defer r.Close() //nolint:errcheck
 
-	_, err = io.Copy(ioutil.Discard, r)
-	return err
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
 
 func runVerifyCommand(ctx context.Context, rep *repo.Repository) error {
 	mgr := snapshot.NewManager(rep)
 
-	v := &verifier{
-		mgr:       mgr,
-		om:        rep.Objects,
-		startTime: time.Now(),
-		workQueue: parallelwork.NewQueue(),
-		seen:      map[object.ID]bool{},
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	defer signal.Stop(sigCh)
+
+	go func() {
+		if _, ok := <-sigCh; ok {
+			log.Warningf("interrupted, waiting for in-flight verification to stop...")
+			cancel()
+		}
+	}()
+
+	opts := flagVerifyOptions()
+
+	if !*verifyCommandAllSources && len(*verifyCommandSources) == 1 {
+		opts = mergeVerifyPolicyForSource(ctx, rep, *verifyCommandSources, opts)
 	}
 
-	if err := enqueueRootsToVerify(ctx, v, mgr); err != nil {
-		return err
+	v := newVerifier(mgr, rep.Objects, opts)
+
+	var reportFile *os.File
+	if *verifyCommandReportFile != "" {
+		f, err := os.Create(*verifyCommandReportFile)
+		if err != nil {
+			return fmt.Errorf("unable to create report file: %v", err)
+		}
+		defer f.Close() //nolint:errcheck
+
+		reportFile = f
+
+		if verify.ReportFormat(*verifyCommandReportFormat) == verify.ReportFormatNDJSON {
+			v.reportWriter = verify.NewReportWriter(f, verify.ReportFormatNDJSON)
+		} else {
+			v.reportWriter = verify.NewReportWriter(nil, verify.ReportFormatJSON)
+		}
+	}
+
+	switch {
+	case *verifyCommandResume:
+		if *verifyCommandCheckpointFile == "" {
+			return errors.New("--resume requires --checkpoint-file")
+		}
+
+		if err := resumeFromCheckpoint(ctx, v, mgr, *verifyCommandCheckpointFile); err != nil {
+			return err
+		}
+	default:
+		if err := enqueueRootsToVerify(ctx, v, mgr); err != nil {
+			return err
+		}
 	}
 
 	v.workQueue.ProgressCallback = v.progressCallback
-	v.workQueue.Process(*verifyCommandParallel)
 
-	if len(v.errors) == 0 {
-		return nil
+	go func() {
+		<-ctx.Done()
+		v.workQueue.Close()
+	}()
+
+	checkpointDone := make(chan struct{})
+	if *verifyCommandCheckpointFile != "" {
+		go runCheckpointLoop(ctx, v, *verifyCommandCheckpointFile, *verifyCommandCheckpointInterval, checkpointDone)
+	}
+
+	v.workQueue.Process(opts.parallel)
+	close(checkpointDone)
+
+	log.Infof("verified %v, retried %v, skipped %v, failed %v objects", v.numVerified, v.numRetried, v.numSkipped, v.numFailed)
+
+	if v.reportWriter != nil {
+		// Always append the final manifest, regardless of --report-format: for
+		// ReportFormatNDJSON the per-object results were already streamed to reportFile as
+		// they were recorded, but --from-report needs the aggregate summary/failed list
+		// too, so the manifest goes in as one more trailing JSON value either way.
+		report := v.reportWriter.Finish()
+		if err := verify.WriteManifest(reportFile, report); err != nil {
+			log.Warningf("unable to write report file: %v", err)
+		}
 	}
 
-	return fmt.Errorf("encountered %v errors", len(v.errors))
+	if cancelled(ctx) {
+		return errVerifyInterrupted
+	}
+
+	if numErrs := len(v.errSink.Errors()); numErrs > 0 {
+		return fmt.Errorf("encountered %v errors", numErrs)
+	}
+
+	return nil
 }
 
 func enqueueRootsToVerify(ctx context.Context, v *verifier, mgr *snapshot.Manager) error {
+	if *verifyCommandFromReport != "" {
+		return enqueueFromReport(ctx, v, mgr, *verifyCommandFromReport)
+	}
+
 	manifests, err := loadSourceManifests(mgr, *verifyCommandAllSources, *verifyCommandSources)
 	if err != nil {
 		return err
@@ -241,6 +553,32 @@ func enqueueRootsToVerify(ctx context.Context, v *verifier, mgr *snapshot.Manage
 	return nil
 }
 
+// enqueueFromReport seeds the verify queue with only the objects that failed in a
+// previous run, as recorded in a report written by a prior `--report-file` invocation.
+func enqueueFromReport(ctx context.Context, v *verifier, mgr *snapshot.Manager, reportPath string) error {
+	f, err := os.Open(reportPath)
+	if err != nil {
+		return fmt.Errorf("unable to open report file: %v", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	report, err := verify.ReadManifest(f)
+	if err != nil {
+		return fmt.Errorf("unable to parse report file: %v", err)
+	}
+
+	for _, res := range report.Failed {
+		oid, err := parseObjectID(ctx, mgr, res.ObjectID)
+		if err != nil {
+			return err
+		}
+
+		v.enqueueVerifyObject(ctx, oid, res.Path, res.ExpectedLength)
+	}
+
+	return nil
+}
+
 func loadSourceManifests(mgr *snapshot.Manager, all bool, sources []string) ([]*snapshot.Manifest, error) {
 	var manifestIDs []string
 	if *verifyCommandAllSources {
@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kopia/kopia/object"
+	"github.com/kopia/kopia/snapshot"
+)
+
+var (
+	verifyCommandCheckpointFile     = verifyCommand.Flag("checkpoint-file", "Periodically save verify progress to the given file so a long run can be resumed").String()
+	verifyCommandCheckpointInterval = verifyCommand.Flag("checkpoint-interval", "How often to save --checkpoint-file").Default("5m").Duration()
+	verifyCommandResume             = verifyCommand.Flag("resume", "Resume a previous run from --checkpoint-file instead of re-walking all roots").Bool()
+)
+
+type workItemKind int
+
+// Kinds of work items tracked by the verifier, used both to dispatch to the right
+// do*() method and to persist pending work in a checkpoint.
+const (
+	workItemDirectory workItemKind = iota
+	workItemObject
+)
+
+// workItem is the descriptor attached to each parallelwork.Queue entry enqueued by the
+// verifier, so that pending work can be introspected via Queue.Snapshot() and persisted.
+type workItem struct {
+	kind           workItemKind
+	oid            object.ID
+	path           string
+	expectedLength int64
+}
+
+// checkpointWorkItem is the on-disk form of a workItem.
+type checkpointWorkItem struct {
+	Kind           workItemKind `json:"kind"`
+	ObjectID       string       `json:"objectID"`
+	Path           string       `json:"path"`
+	ExpectedLength int64        `json:"expectedLength"`
+}
+
+// checkpoint is the on-disk snapshot of an in-progress verify run, written periodically to
+// --checkpoint-file and consumed by --resume.
+type checkpoint struct {
+	RootsFingerprint string               `json:"rootsFingerprint"`
+	SavedAt          time.Time            `json:"savedAt"`
+	Seen             []string             `json:"seen"`
+	Pending          []checkpointWorkItem `json:"pending"`
+	Errors           []string             `json:"errors"`
+}
+
+// rootsFingerprint identifies the set of roots a verify run covers, so --resume can fail
+// fast when pointed at a checkpoint produced by a run over a different set of roots.
+func rootsFingerprint() string {
+	dirIDs := append([]string(nil), *verifyCommandDirObjectIDs...)
+	fileIDs := append([]string(nil), *verifyCommandFileObjectIDs...)
+	sources := append([]string(nil), *verifyCommandSources...)
+	sort.Strings(dirIDs)
+	sort.Strings(fileIDs)
+	sort.Strings(sources)
+
+	return strings.Join([]string{
+		fmt.Sprintf("all-sources=%v", *verifyCommandAllSources),
+		fmt.Sprintf("sources=%v", sources),
+		fmt.Sprintf("directory-id=%v", dirIDs),
+		fmt.Sprintf("file-id=%v", fileIDs),
+	}, "|")
+}
+
+// saveCheckpoint atomically writes v's current progress to path.
+func (v *verifier) saveCheckpoint(path string) error {
+	// Only IDs that have actually Completed count as "seen": an ID that's merely
+	// Scheduled (e.g. still sitting in the pending queue) is also captured in
+	// v.workQueue.Snapshot() below, and seeding it here as well would make resume try to
+	// both skip it (as already seen) and re-verify it (as pending) for the same oid.
+	seenIDs := v.scheduler.CompletedIDs()
+	seen := make([]string, len(seenIDs))
+	for i, oid := range seenIDs {
+		seen[i] = oid.String()
+	}
+
+	sinkErrors := v.errSink.Errors()
+	errs := make([]string, len(sinkErrors))
+	for i, e := range sinkErrors {
+		errs[i] = e.Error()
+	}
+
+	// Pending work covers both items still waiting in the queue and items a worker is
+	// actively verifying right now: an in-flight item isn't in CompletedIDs (it hasn't
+	// finished) or in Snapshot (it's not waiting anymore), so without InFlight it would
+	// silently fall out of the checkpoint entirely if the process died before it finished.
+	var pending []checkpointWorkItem
+	for _, d := range append(v.workQueue.Snapshot(), v.workQueue.InFlight()...) {
+		wi, ok := d.(workItem)
+		if !ok {
+			continue
+		}
+		pending = append(pending, checkpointWorkItem{
+			Kind:           wi.kind,
+			ObjectID:       wi.oid.String(),
+			Path:           wi.path,
+			ExpectedLength: wi.expectedLength,
+		})
+	}
+
+	cp := checkpoint{
+		RootsFingerprint: rootsFingerprint(),
+		SavedAt:          time.Now(),
+		Seen:             seen,
+		Pending:          pending,
+		Errors:           errs,
+	}
+
+	tmpPath := path + ".tmp"
+
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	if err := json.NewEncoder(f).Encode(cp); err != nil {
+		f.Close() //nolint:errcheck
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// runCheckpointLoop periodically saves v's progress to path until ctx is cancelled or done
+// is closed. Write errors are logged but do not abort the verify run.
+func runCheckpointLoop(ctx context.Context, v *verifier, path string, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := v.saveCheckpoint(path); err != nil {
+				log.Warningf("unable to save checkpoint: %v", err)
+			}
+		case <-ctx.Done():
+			return
+		case <-done:
+			return
+		}
+	}
+}
+
+// resumeFromCheckpoint seeds v from a checkpoint previously written to path instead of
+// walking roots from scratch via enqueueRootsToVerify.
+func resumeFromCheckpoint(ctx context.Context, v *verifier, mgr *snapshot.Manager, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open checkpoint: %v", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	var cp checkpoint
+	if err := json.NewDecoder(f).Decode(&cp); err != nil {
+		return fmt.Errorf("unable to parse checkpoint: %v", err)
+	}
+
+	if cp.RootsFingerprint != rootsFingerprint() {
+		return errors.New("checkpoint does not match the current set of roots; refusing to resume")
+	}
+
+	seenIDs := make([]object.ID, len(cp.Seen))
+	for i, s := range cp.Seen {
+		oid, err := parseObjectID(ctx, mgr, s)
+		if err != nil {
+			return fmt.Errorf("invalid object ID %q in checkpoint: %v", s, err)
+		}
+		seenIDs[i] = oid
+	}
+	v.scheduler.Seed(seenIDs)
+
+	seededErrors := make([]error, len(cp.Errors))
+	for i, e := range cp.Errors {
+		seededErrors[i] = errors.New(e)
+	}
+	v.errSink.Seed(seededErrors)
+
+	for _, wi := range cp.Pending {
+		oid, err := parseObjectID(ctx, mgr, wi.ObjectID)
+		if err != nil {
+			return fmt.Errorf("invalid object ID %q in checkpoint: %v", wi.ObjectID, err)
+		}
+
+		switch wi.Kind {
+		case workItemDirectory:
+			v.enqueueVerifyDirectoryUnchecked(ctx, oid, wi.Path)
+		case workItemObject:
+			v.enqueueVerifyObjectUnchecked(ctx, oid, wi.Path, wi.ExpectedLength)
+		}
+	}
+
+	log.Infof("resumed from checkpoint saved at %v: %v seen, %v pending", cp.SavedAt.Format(timeFormat), len(cp.Seen), len(cp.Pending))
+
+	return nil
+}
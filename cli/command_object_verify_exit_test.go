@@ -0,0 +1,15 @@
+package cli
+
+import "testing"
+
+func TestErrVerifyInterrupted_ExitCode(t *testing.T) {
+	var coder ExitCoder = errVerifyInterrupted
+
+	if got := coder.ExitCode(); got != exitCodeVerifyInterrupted {
+		t.Fatalf("got exit code %v, want %v", got, exitCodeVerifyInterrupted)
+	}
+
+	if errVerifyInterrupted.Error() == "" {
+		t.Fatalf("expected a non-empty error message")
+	}
+}
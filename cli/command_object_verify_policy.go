@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/policy"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+)
+
+var (
+	verifyApplyPolicyCommand = verifyCommand.Command("apply-policy", "Verify all sources whose policy says they are due for verification")
+)
+
+// mergeVerifyPolicyForSource loads the VerifyPolicy for sources[0], if any, and uses it to
+// fill in any of opts' fields that weren't explicitly overridden via a KOPIA_VERIFY_*
+// environment variable or a command-line flag.
+func mergeVerifyPolicyForSource(ctx context.Context, rep *repo.Repository, sources []string, opts verifyOptions) verifyOptions {
+	src, err := snapshot.ParseSourceInfo(sources[0], getHostName(), getUserName())
+	if err != nil {
+		return opts
+	}
+
+	p, err := policy.NewManager(rep.Manifests).GetVerifyPolicy(ctx, src)
+	if err != nil {
+		log.Warningf("unable to load verify policy for %v: %v", src, err)
+		return opts
+	}
+
+	return applyVerifyPolicy(opts, flagVerifyOptionsSetByUser(), p)
+}
+
+// applyVerifyPolicy overrides fields of opts that set did not mark as set by an explicit
+// flag or environment variable with the corresponding value from p, giving policy the
+// lowest precedence of the three (policy -> environment -> flag) layers. Comparing opts'
+// fields against their hardcoded defaults can't make this distinction: an explicit
+// --parallel=16 is indistinguishable from "--parallel not passed" that way, so it would be
+// silently clobbered by policy.
+func applyVerifyPolicy(opts verifyOptions, set verifyOptionsSetByUser, p *policy.VerifyPolicy) verifyOptions {
+	if p == nil {
+		return opts
+	}
+
+	if !set.parallel && p.Parallel != nil {
+		opts.parallel = *p.Parallel
+	}
+
+	if !set.filesPercent && p.FilesPercent != nil {
+		opts.filesPercent = *p.FilesPercent
+	}
+
+	if !set.errorThreshold && p.MaxErrors != nil {
+		opts.errorThreshold = *p.MaxErrors
+	}
+
+	if !set.maxRetries && p.MaxRetries != nil {
+		opts.retryPolicy.MaxRetries = *p.MaxRetries
+	}
+
+	if !set.initialBackoff && p.RetryInitialBackoff != nil {
+		opts.retryPolicy.InitialBackoff = *p.RetryInitialBackoff
+	}
+
+	if !set.maxBackoff && p.RetryMaxBackoff != nil {
+		opts.retryPolicy.MaxBackoff = *p.RetryMaxBackoff
+	}
+
+	return opts
+}
+
+// runVerifyApplyPolicyCommand walks every known source and verifies those whose
+// VerifyPolicy.Schedule says they're due, using that source's policy to configure the run.
+func runVerifyApplyPolicyCommand(ctx context.Context, rep *repo.Repository) error {
+	mgr := snapshot.NewManager(rep)
+	pmgr := policy.NewManager(rep.Manifests)
+
+	manifests, err := mgr.LoadSnapshots(mgr.ListSnapshotManifests(nil))
+	if err != nil {
+		return err
+	}
+
+	latestBySource := map[snapshot.SourceInfo]*snapshot.Manifest{}
+	for _, man := range manifests {
+		if existing, ok := latestBySource[man.Source]; !ok || man.StartTime.After(existing.StartTime) {
+			latestBySource[man.Source] = man
+		}
+	}
+
+	now := time.Now()
+
+	var verifyErr error
+
+	for src, man := range latestBySource {
+		p, err := pmgr.GetVerifyPolicy(ctx, src)
+		if err != nil {
+			return err
+		}
+
+		if p == nil || !p.Schedule.Due(p.LastVerified, now) {
+			continue
+		}
+
+		log.Infof("verifying %v (due per policy)...", src)
+
+		if err := verifySourceForPolicy(ctx, mgr, rep, src, man, p); err != nil {
+			verifyErr = err
+			log.Warningf("%v", err)
+			continue
+		}
+
+		if err := pmgr.MarkVerified(ctx, src, now); err != nil {
+			log.Warningf("unable to update verify policy for %v: %v", src, err)
+		}
+	}
+
+	return verifyErr
+}
+
+func verifySourceForPolicy(ctx context.Context, mgr *snapshot.Manager, rep *repo.Repository, src snapshot.SourceInfo, man *snapshot.Manifest, p *policy.VerifyPolicy) error {
+	opts := applyVerifyPolicy(flagVerifyOptions(), flagVerifyOptionsSetByUser(), p)
+
+	v := newVerifier(mgr, rep.Objects, opts)
+	v.workQueue.ProgressCallback = v.progressCallback
+
+	if man.RootEntry == nil {
+		return nil
+	}
+
+	path := fmt.Sprintf("%v@%v", src, man.StartTime.Format(timeFormat))
+	if man.RootEntry.Type == fs.EntryTypeDirectory {
+		v.enqueueVerifyDirectory(ctx, man.RootObjectID(), path)
+	} else {
+		v.enqueueVerifyObject(ctx, man.RootObjectID(), path, -1)
+	}
+
+	v.workQueue.Process(opts.parallel)
+
+	if numErrs := len(v.errSink.Errors()); numErrs > 0 {
+		return fmt.Errorf("source %v failed verification with %v errors", src, numErrs)
+	}
+
+	return nil
+}
+
+func init() {
+	verifyApplyPolicyCommand.Action(repositoryAction(runVerifyApplyPolicyCommand))
+}
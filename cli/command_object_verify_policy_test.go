@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kopia/kopia/policy"
+	"github.com/kopia/kopia/verify"
+)
+
+func TestApplyVerifyPolicy_FillsInUnsetFields(t *testing.T) {
+	opts := verifyOptions{
+		parallel:       16,
+		filesPercent:   0,
+		errorThreshold: 0,
+		retryPolicy:    verify.RetryPolicy{MaxRetries: 5, InitialBackoff: time.Second, MaxBackoff: 30 * time.Second},
+	}
+
+	policyParallel := 4
+	p := &policy.VerifyPolicy{Parallel: &policyParallel}
+
+	got := applyVerifyPolicy(opts, verifyOptionsSetByUser{}, p)
+	if got.parallel != 4 {
+		t.Fatalf("got parallel=%v, want 4 (policy should fill in an unset field)", got.parallel)
+	}
+}
+
+func TestApplyVerifyPolicy_FlagAtDefaultValueStillWins(t *testing.T) {
+	// Regression test: an explicit --parallel=16 (equal to the hardcoded default) must not
+	// be clobbered by policy just because it happens to match the default value.
+	opts := verifyOptions{parallel: 16}
+	set := verifyOptionsSetByUser{parallel: true}
+
+	policyParallel := 4
+	p := &policy.VerifyPolicy{Parallel: &policyParallel}
+
+	got := applyVerifyPolicy(opts, set, p)
+	if got.parallel != 16 {
+		t.Fatalf("got parallel=%v, want 16 (explicit flag must win over policy)", got.parallel)
+	}
+}
+
+func TestApplyVerifyPolicy_NilPolicyIsNoOp(t *testing.T) {
+	opts := verifyOptions{parallel: 16, errorThreshold: 3}
+
+	got := applyVerifyPolicy(opts, verifyOptionsSetByUser{}, nil)
+	if got != opts {
+		t.Fatalf("got %+v, want opts unchanged: %+v", got, opts)
+	}
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kopia/kopia/policy"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+)
+
+var (
+	policyCommands = app.Command("policy", "Manage source policies")
+
+	policySetCommand = policyCommands.Command("set", "Set the policy for a source")
+	policySetSource  = policySetCommand.Arg("source", "Source to set the policy for").Required().String()
+
+	// verify-related knobs; -1 (the default) means "leave unchanged".
+	policySetVerifyParallel     = policySetCommand.Flag("verify-parallel", "Parallelism to use when verifying this source").Default("-1").Int()
+	policySetVerifyFilesPercent = policySetCommand.Flag("verify-files-percent", "Percentage of files to fully read when verifying this source").Default("-1").Int()
+	policySetVerifyMaxErrors    = policySetCommand.Flag("verify-max-errors", "Maximum number of errors to tolerate when verifying this source").Default("-1").Int()
+	policySetVerifyMaxRetries   = policySetCommand.Flag("verify-max-retries", "Maximum number of retries to attempt when verifying this source").Default("-1").Int()
+
+	// Duration/schedule knobs can't use a sentinel default the way the int knobs above do --
+	// 0 is itself a meaningful value (no backoff; a schedule that is never due) -- so each one tracks
+	// whether the user actually passed it via IsSetByUser instead.
+	policySetVerifyRetryInitialBackoffSet bool
+	policySetVerifyRetryMaxBackoffSet     bool
+	policySetVerifyScheduleSet            bool
+
+	policySetVerifyRetryInitialBackoff = policySetCommand.Flag("verify-retry-initial-backoff", "Initial backoff delay when verifying this source").IsSetByUser(&policySetVerifyRetryInitialBackoffSet).Duration()
+	policySetVerifyRetryMaxBackoff     = policySetCommand.Flag("verify-retry-max-backoff", "Maximum backoff delay when verifying this source").IsSetByUser(&policySetVerifyRetryMaxBackoffSet).Duration()
+	policySetVerifySchedule            = policySetCommand.Flag("verify-schedule", "How often this source should be re-verified by 'verify apply-policy'").IsSetByUser(&policySetVerifyScheduleSet).Duration()
+)
+
+func runPolicySetCommand(ctx context.Context, rep *repo.Repository) error {
+	src, err := snapshot.ParseSourceInfo(*policySetSource, getHostName(), getUserName())
+	if err != nil {
+		return fmt.Errorf("error parsing %q: %v", *policySetSource, err)
+	}
+
+	mgr := policy.NewManager(rep.Manifests)
+
+	p, err := mgr.GetVerifyPolicy(ctx, src)
+	if err != nil {
+		return err
+	}
+
+	if p == nil {
+		p = &policy.VerifyPolicy{}
+	}
+
+	if *policySetVerifyParallel >= 0 {
+		p.Parallel = policySetVerifyParallel
+	}
+
+	if *policySetVerifyFilesPercent >= 0 {
+		p.FilesPercent = policySetVerifyFilesPercent
+	}
+
+	if *policySetVerifyMaxErrors >= 0 {
+		p.MaxErrors = policySetVerifyMaxErrors
+	}
+
+	if *policySetVerifyMaxRetries >= 0 {
+		p.MaxRetries = policySetVerifyMaxRetries
+	}
+
+	if policySetVerifyRetryInitialBackoffSet {
+		p.RetryInitialBackoff = policySetVerifyRetryInitialBackoff
+	}
+
+	if policySetVerifyRetryMaxBackoffSet {
+		p.RetryMaxBackoff = policySetVerifyRetryMaxBackoff
+	}
+
+	if policySetVerifyScheduleSet {
+		p.Schedule = &policy.VerifySchedule{Interval: *policySetVerifySchedule}
+	}
+
+	return mgr.SetVerifyPolicy(ctx, src, p)
+}
+
+func init() {
+	policySetCommand.Action(repositoryAction(runPolicySetCommand))
+}
@@ -0,0 +1,199 @@
+// Package parallelwork implements a queue of work items that can be processed in parallel
+// by a fixed-size worker pool, with new items allowed to be enqueued while processing is
+// underway.
+package parallelwork
+
+import (
+	"container/list"
+	"sync"
+)
+
+// CallbackFunc is a unit of work to be processed by the queue.
+type CallbackFunc func()
+
+// Item pairs a work item's callback with a lightweight, caller-defined descriptor
+// identifying it (e.g. for checkpointing), so that pending items can be inspected via
+// Snapshot() without needing to invoke or reflect into the callback closures themselves.
+type Item struct {
+	Descriptor interface{}
+	Callback   CallbackFunc
+}
+
+// Queue represents a queue of work items that's processed by a pool of worker goroutines.
+// Work items may enqueue more work items while being processed.
+type Queue struct {
+	// ProgressCallback, when set, is invoked periodically as work items are discovered
+	// and completed, with the number enqueued, currently active and completed so far.
+	ProgressCallback func(enqueued, active, completed int64)
+
+	mu           sync.Mutex
+	cond         *sync.Cond
+	items        *list.List
+	active       int64
+	enqueued     int64
+	done         int64
+	closed       bool
+	inFlight     map[int64]interface{}
+	nextInFlight int64
+}
+
+// NewQueue creates a new empty Queue.
+func NewQueue() *Queue {
+	q := &Queue{
+		items:    list.New(),
+		inFlight: map[int64]interface{}{},
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	return q
+}
+
+// EnqueueFront adds a work item to the front of the queue, so that it's processed before
+// any items already waiting. descriptor is an opaque value returned verbatim by Snapshot();
+// pass nil if the caller has no use for introspecting pending items.
+func (q *Queue) EnqueueFront(descriptor interface{}, callback CallbackFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.items.PushFront(Item{descriptor, callback})
+	q.enqueued++
+	q.cond.Signal()
+}
+
+// EnqueueBack adds a work item to the back of the queue.
+func (q *Queue) EnqueueBack(descriptor interface{}, callback CallbackFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.items.PushBack(Item{descriptor, callback})
+	q.enqueued++
+	q.cond.Signal()
+}
+
+// Snapshot returns the descriptors of all items currently waiting to be processed, in
+// order. It does not include items that are currently being executed by a worker; use
+// InFlight for those.
+func (q *Queue) Snapshot() []interface{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	result := make([]interface{}, 0, q.items.Len())
+	for e := q.items.Front(); e != nil; e = e.Next() {
+		result = append(result, e.Value.(Item).Descriptor)
+	}
+
+	return result
+}
+
+// InFlight returns the descriptors of items a worker is currently executing, i.e. popped
+// from the queue by popFront but not yet finished. A caller checkpointing progress needs
+// both Snapshot and InFlight to cover every item that isn't done yet: an item a worker is
+// midway through isn't in either the waiting list or (until its callback returns and
+// Process's caller reports it) the completed count.
+func (q *Queue) InFlight() []interface{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	result := make([]interface{}, 0, len(q.inFlight))
+	for _, descriptor := range q.inFlight {
+		result = append(result, descriptor)
+	}
+
+	return result
+}
+
+// popFront removes and returns the next item's callback, registering its descriptor as
+// in-flight (see InFlight) under the id that must be passed to completeInFlight once the
+// callback has run.
+func (q *Queue) popFront() (callback CallbackFunc, inFlightID int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for q.items.Len() == 0 && q.active > 0 && !q.closed {
+		q.cond.Wait()
+	}
+
+	if q.closed || q.items.Len() == 0 {
+		return nil, 0
+	}
+
+	e := q.items.Front()
+	item := e.Value.(Item)
+	q.items.Remove(e)
+	q.active++
+
+	q.nextInFlight++
+	id := q.nextInFlight
+	q.inFlight[id] = item.Descriptor
+
+	return item.Callback, id
+}
+
+// completeInFlight clears the in-flight descriptor registered by popFront under id, once
+// that item's callback has returned.
+func (q *Queue) completeInFlight(id int64) {
+	q.mu.Lock()
+	delete(q.inFlight, id)
+	q.mu.Unlock()
+}
+
+func (q *Queue) reportProgress() {
+	if q.ProgressCallback == nil {
+		return
+	}
+
+	q.mu.Lock()
+	enqueued, active, done := q.enqueued, q.active, q.done
+	q.mu.Unlock()
+
+	q.ProgressCallback(enqueued, active, done)
+}
+
+// Process runs the queue with the given number of parallel workers and blocks until all
+// work items (including those enqueued by other work items) have completed.
+func (q *Queue) Process(parallelism int) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < parallelism; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				callback, inFlightID := q.popFront()
+				if callback == nil {
+					return
+				}
+
+				q.reportProgress()
+				callback()
+				q.completeInFlight(inFlightID)
+
+				q.mu.Lock()
+				q.active--
+				q.done++
+				q.mu.Unlock()
+
+				q.reportProgress()
+				q.cond.Broadcast()
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// Close unblocks all workers currently waiting for new work, causing Process() to return
+// even if the queue is not empty. It's used to abort processing, e.g. on context cancellation.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+}
@@ -0,0 +1,110 @@
+package parallelwork
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestQueue_SnapshotReflectsPendingNotCompleted(t *testing.T) {
+	q := NewQueue()
+
+	q.EnqueueBack("a", func() {})
+	q.EnqueueBack("b", func() {})
+
+	if got := q.Snapshot(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("got %v, want [a b]", got)
+	}
+}
+
+func TestQueue_EnqueueFrontTakesPriority(t *testing.T) {
+	q := NewQueue()
+
+	q.EnqueueBack("back", func() {})
+	q.EnqueueFront("front", func() {})
+
+	got := q.Snapshot()
+	if len(got) != 2 || got[0] != "front" || got[1] != "back" {
+		t.Fatalf("got %v, want [front back]", got)
+	}
+}
+
+func TestQueue_ProcessRunsAllItemsIncludingEnqueuedDuringProcessing(t *testing.T) {
+	q := NewQueue()
+
+	var completed int64
+
+	q.EnqueueBack("a", func() {
+		atomic.AddInt64(&completed, 1)
+		q.EnqueueBack("b", func() {
+			atomic.AddInt64(&completed, 1)
+		})
+	})
+
+	q.Process(2)
+
+	if got := atomic.LoadInt64(&completed); got != 2 {
+		t.Fatalf("got %v completed items, want 2", got)
+	}
+
+	if got := q.Snapshot(); len(got) != 0 {
+		t.Fatalf("got %v still pending after Process, want none", got)
+	}
+}
+
+func TestQueue_InFlightCoversItemBeingProcessed(t *testing.T) {
+	q := NewQueue()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	q.EnqueueBack("a", func() {
+		close(started)
+		<-release
+	})
+
+	done := make(chan struct{})
+	go func() {
+		q.Process(1)
+		close(done)
+	}()
+
+	<-started
+
+	// While the callback is running, "a" has left Snapshot (it's no longer waiting) but
+	// hasn't completed yet either; it must still show up as in-flight so a checkpoint
+	// taken at this instant doesn't silently drop it.
+	if got := q.Snapshot(); len(got) != 0 {
+		t.Fatalf("got snapshot %v while item is in flight, want none", got)
+	}
+
+	if got := q.InFlight(); len(got) != 1 || got[0] != "a" {
+		t.Fatalf("got in-flight %v, want [a]", got)
+	}
+
+	close(release)
+	<-done
+
+	if got := q.InFlight(); len(got) != 0 {
+		t.Fatalf("got in-flight %v after completion, want none", got)
+	}
+}
+
+func TestQueue_CloseStopsProcessingEarly(t *testing.T) {
+	q := NewQueue()
+
+	var completed int64
+
+	q.EnqueueBack("a", func() {
+		atomic.AddInt64(&completed, 1)
+		q.Close()
+	})
+	q.EnqueueBack("b", func() {
+		atomic.AddInt64(&completed, 1)
+	})
+
+	q.Process(1)
+
+	if got := atomic.LoadInt64(&completed); got != 1 {
+		t.Fatalf("got %v completed items, want 1 (b must not run after Close)", got)
+	}
+}
@@ -0,0 +1,180 @@
+package object
+
+import (
+	"sync"
+	"time"
+)
+
+// VerifyResult is the outcome of verifying a single object, shared by every caller that
+// scheduled that object's verification.
+type VerifyResult struct {
+	Length int64
+	Err    error
+}
+
+// verifyTransfer tracks one object ID's in-flight or recently-completed verification.
+type verifyTransfer struct {
+	done        chan struct{}
+	closeOnce   sync.Once
+	result      VerifyResult
+	completedAt time.Time
+}
+
+// close closes t.done exactly once, tolerating a Seed and a later Complete (or two
+// Completes) racing for the same transfer instead of panicking on a double close.
+func (t *verifyTransfer) close(result VerifyResult) {
+	t.closeOnce.Do(func() {
+		t.result = result
+		t.completedAt = time.Now()
+		close(t.done)
+	})
+}
+
+// VerifyScheduler deduplicates concurrent verification requests for the same object ID,
+// modeled after a transfer manager (e.g. Docker's distribution xfer package): when multiple
+// callers Schedule() the same ID while it's already in flight, only the first is asked to do
+// the work; the rest attach to that in-flight verifyTransfer and receive its result once it
+// completes, instead of redundantly re-reading the object. Completed results are retained
+// for TTL so a burst of requests for a recently-verified object don't re-trigger work either.
+//
+// A single VerifyScheduler can be shared across concurrent callers operating on the same
+// repository (CLI verify, a future server-side background verifier, a repair tool, ...) in
+// place of each caller keeping its own "seen map[ID]bool + sync.Mutex".
+type VerifyScheduler struct {
+	// TTL is how long a completed result is retained and handed to new callers without
+	// re-running verify. Zero (the default) means a completed result is retained
+	// indefinitely, e.g. for a CLI verify run where the same object should never be
+	// re-verified twice in one pass.
+	TTL time.Duration
+
+	mu        sync.Mutex
+	transfers map[ID]*verifyTransfer
+}
+
+// NewVerifyScheduler creates a VerifyScheduler that caches completed results for ttl.
+func NewVerifyScheduler(ttl time.Duration) *VerifyScheduler {
+	return &VerifyScheduler{
+		TTL:       ttl,
+		transfers: map[ID]*verifyTransfer{},
+	}
+}
+
+// Schedule requests verification of oid.
+//
+// If no verification of oid is currently in flight or cached within TTL, it returns
+// isNew=true: the caller must itself verify oid and report the outcome via Complete, which
+// will be delivered on the returned channel.
+//
+// Otherwise it returns isNew=false: a verification of oid is already in flight or was
+// recently completed, and the caller must not redo the work; the returned channel will
+// receive that transfer's result once available (or immediately, if it already completed).
+func (s *VerifyScheduler) Schedule(oid ID) (ch <-chan VerifyResult, isNew bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, ok := s.transfers[oid]; ok && !s.expiredLocked(t) {
+		return subscribe(t), false
+	}
+
+	t := &verifyTransfer{done: make(chan struct{})}
+	s.transfers[oid] = t
+
+	return subscribe(t), true
+}
+
+func (s *VerifyScheduler) expiredLocked(t *verifyTransfer) bool {
+	select {
+	case <-t.done:
+	default:
+		return false // still in flight, not eligible for eviction
+	}
+
+	return s.TTL > 0 && time.Since(t.completedAt) > s.TTL
+}
+
+// subscribe returns a channel that receives t's result exactly once, whether t is already
+// complete or completes later.
+func subscribe(t *verifyTransfer) <-chan VerifyResult {
+	ch := make(chan VerifyResult, 1)
+
+	go func() {
+		<-t.done
+		ch <- t.result
+	}()
+
+	return ch
+}
+
+// Complete records result as the outcome of verifying oid and wakes every caller waiting on
+// the channel returned by the Schedule call that returned isNew=true for oid. It should be
+// called exactly once per such call; a redundant or racing call (e.g. against a Seed for the
+// same oid) is ignored rather than panicking.
+func (s *VerifyScheduler) Complete(oid ID, result VerifyResult) {
+	s.mu.Lock()
+	t, ok := s.transfers[oid]
+	s.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	t.close(result)
+}
+
+// Seed marks each of oids as already verified, e.g. when resuming a run from a checkpoint
+// that recorded them as seen in a previous process. Schedule calls for a seeded ID report
+// isNew=false until TTL elapses.
+//
+// An oid that's already tracked (e.g. because a caller has Scheduled it and its
+// verification is still in flight) is left alone rather than replaced, so that the in-flight
+// transfer's own eventual Complete is the one that closes it.
+func (s *VerifyScheduler) Seed(oids []ID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, oid := range oids {
+		if _, ok := s.transfers[oid]; ok {
+			continue
+		}
+
+		t := &verifyTransfer{done: make(chan struct{})}
+		t.close(VerifyResult{})
+		s.transfers[oid] = t
+	}
+}
+
+// IDs returns every object ID currently tracked (in flight, or completed within TTL),
+// e.g. to list every object a verify run has reached. For a checkpoint's "seen" set use
+// CompletedIDs instead.
+func (s *VerifyScheduler) IDs() []ID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]ID, 0, len(s.transfers))
+	for oid := range s.transfers {
+		ids = append(ids, oid)
+	}
+
+	return ids
+}
+
+// CompletedIDs returns the object IDs whose verification has actually finished (Complete
+// was called), excluding any that are still in flight or only pending in a work queue. This
+// is the set safe to persist as a checkpoint's "seen" list: unlike IDs, it won't include an
+// oid that's been Scheduled but not yet Completed, which would otherwise collide with that
+// oid being re-enqueued as pending work on resume (see Seed).
+func (s *VerifyScheduler) CompletedIDs() []ID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]ID, 0, len(s.transfers))
+	for oid, t := range s.transfers {
+		select {
+		case <-t.done:
+			ids = append(ids, oid)
+		default:
+		}
+	}
+
+	return ids
+}
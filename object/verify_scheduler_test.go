@@ -0,0 +1,108 @@
+package object
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerifyScheduler_ScheduleThenComplete(t *testing.T) {
+	s := NewVerifyScheduler(0)
+
+	ch, isNew := s.Schedule("oid1")
+	if !isNew {
+		t.Fatalf("expected first Schedule of oid1 to be new")
+	}
+
+	s.Complete("oid1", VerifyResult{Length: 42})
+
+	got := <-ch
+	if got.Length != 42 {
+		t.Fatalf("got result %+v, want Length=42", got)
+	}
+
+	ch2, isNew2 := s.Schedule("oid1")
+	if isNew2 {
+		t.Fatalf("expected Schedule of an already-completed oid1 to not be new")
+	}
+
+	if got2 := <-ch2; got2.Length != 42 {
+		t.Fatalf("got result %+v, want Length=42", got2)
+	}
+}
+
+func TestVerifyScheduler_SeedThenPendingCompleteDoesNotPanic(t *testing.T) {
+	// Regression test: a Complete for an oid that Seed has already marked as verified
+	// must not collide with the transfer Seed already closed.
+	s := NewVerifyScheduler(0)
+
+	s.Seed([]ID{"oid1", "oid2"})
+
+	if ids := s.CompletedIDs(); len(ids) != 2 {
+		t.Fatalf("got %v completed ids, want 2", ids)
+	}
+
+	// Schedule reports isNew=false for the seeded oid1, and a later Complete for it
+	// must not panic.
+	if _, isNew := s.Schedule("oid1"); isNew {
+		t.Fatalf("expected seeded oid1 to not be new")
+	}
+
+	s.Complete("oid1", VerifyResult{Length: 7})
+}
+
+func TestVerifyScheduler_SeedSkipsAlreadyTrackedID(t *testing.T) {
+	s := NewVerifyScheduler(0)
+
+	ch, isNew := s.Schedule("oid1")
+	if !isNew {
+		t.Fatalf("expected first Schedule of oid1 to be new")
+	}
+
+	// Seed races with an in-flight Schedule for the same oid: Seed must leave the
+	// in-flight transfer alone.
+	s.Seed([]ID{"oid1"})
+
+	if ids := s.CompletedIDs(); len(ids) != 0 {
+		t.Fatalf("got %v completed ids, want none (oid1 is still in flight)", ids)
+	}
+
+	s.Complete("oid1", VerifyResult{Length: 5})
+
+	if got := <-ch; got.Length != 5 {
+		t.Fatalf("got result %+v, want Length=5", got)
+	}
+}
+
+func TestVerifyScheduler_DoubleCompleteDoesNotPanic(t *testing.T) {
+	s := NewVerifyScheduler(0)
+
+	s.Schedule("oid1")
+	s.Complete("oid1", VerifyResult{Length: 1})
+	s.Complete("oid1", VerifyResult{Length: 2})
+}
+
+func TestVerifyScheduler_CompletedIDsExcludesInFlight(t *testing.T) {
+	s := NewVerifyScheduler(0)
+
+	s.Schedule("in-flight")
+	s.Schedule("done")
+	s.Complete("done", VerifyResult{})
+
+	ids := s.CompletedIDs()
+	if len(ids) != 1 || ids[0] != "done" {
+		t.Fatalf("got completed ids %v, want only [done]", ids)
+	}
+}
+
+func TestVerifyScheduler_TTLExpiry(t *testing.T) {
+	s := NewVerifyScheduler(time.Millisecond)
+
+	s.Schedule("oid1")
+	s.Complete("oid1", VerifyResult{})
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, isNew := s.Schedule("oid1"); !isNew {
+		t.Fatalf("expected Schedule of an expired oid1 to be new again")
+	}
+}
@@ -0,0 +1,104 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kopia/kopia/snapshot"
+)
+
+// manifest labels used to identify a VerifyPolicy, mirroring the label-based scheme used
+// to store snapshot manifests.
+const (
+	manifestLabelType        = "type"
+	manifestLabelVerifyValue = "verifypolicy"
+	manifestLabelSource      = "source"
+)
+
+// ManifestStore is the subset of the repository's manifest storage that Manager needs.
+// It's satisfied by the repository's manifest manager, the same store snapshot manifests
+// live in.
+type ManifestStore interface {
+	PutManifest(ctx context.Context, labels map[string]string, payload interface{}) (string, error)
+	FindManifests(ctx context.Context, labels map[string]string) ([]string, error)
+	GetManifest(ctx context.Context, id string, payload interface{}) error
+}
+
+// Manager stores and retrieves VerifyPolicy objects from the repository, parallel to how
+// snapshot.Manager stores snapshot manifests.
+type Manager struct {
+	store ManifestStore
+}
+
+// NewManager creates a Manager backed by store.
+func NewManager(store ManifestStore) *Manager {
+	return &Manager{store: store}
+}
+
+func sourceLabels(src snapshot.SourceInfo) map[string]string {
+	return map[string]string{
+		manifestLabelType:   manifestLabelVerifyValue,
+		manifestLabelSource: fmt.Sprintf("%v", src),
+	}
+}
+
+// GetVerifyPolicy returns the VerifyPolicy stored for src, or nil if none is set. Because
+// SetVerifyPolicy adds a new manifest rather than replacing the previous one, a source can
+// have more than one on record; GetVerifyPolicy picks the one with the latest SavedAt
+// instead of assuming the store's FindManifests order reflects write order.
+func (m *Manager) GetVerifyPolicy(ctx context.Context, src snapshot.SourceInfo) (*VerifyPolicy, error) {
+	ids, err := m.store.FindManifests(ctx, sourceLabels(src))
+	if err != nil {
+		return nil, fmt.Errorf("error finding verify policy for %v: %v", src, err)
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var latest *VerifyPolicy
+
+	for _, id := range ids {
+		var p VerifyPolicy
+		if err := m.store.GetManifest(ctx, id, &p); err != nil {
+			return nil, fmt.Errorf("error loading verify policy for %v: %v", src, err)
+		}
+
+		if latest == nil || p.SavedAt.After(latest.SavedAt) {
+			latest = &p
+		}
+	}
+
+	return latest, nil
+}
+
+// SetVerifyPolicy persists p as the VerifyPolicy for src, stamping it with the current time
+// so a later GetVerifyPolicy can tell it apart from any older manifest left behind by a
+// previous SetVerifyPolicy call for the same source.
+func (m *Manager) SetVerifyPolicy(ctx context.Context, src snapshot.SourceInfo, p *VerifyPolicy) error {
+	p.SavedAt = time.Now()
+
+	if _, err := m.store.PutManifest(ctx, sourceLabels(src), p); err != nil {
+		return fmt.Errorf("error saving verify policy for %v: %v", src, err)
+	}
+
+	return nil
+}
+
+// MarkVerified updates src's VerifyPolicy to record that it was verified at t, creating an
+// empty policy if none existed yet.
+func (m *Manager) MarkVerified(ctx context.Context, src snapshot.SourceInfo, t time.Time) error {
+	p, err := m.GetVerifyPolicy(ctx, src)
+	if err != nil {
+		return err
+	}
+
+	if p == nil {
+		p = &VerifyPolicy{}
+	}
+
+	p.LastVerified = t
+
+	return m.SetVerifyPolicy(ctx, src, p)
+}
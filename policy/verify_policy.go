@@ -0,0 +1,47 @@
+// Package policy stores repository-wide, per-source settings that would otherwise have to
+// be passed as flags on every invocation of a scheduled command.
+package policy
+
+import "time"
+
+// VerifyPolicy holds the `kopia object verify` knobs that can be associated with a
+// snapshot source and persisted in the repository, so that scheduled/cron verification
+// jobs don't have to hard-code flags.
+type VerifyPolicy struct {
+	Parallel            *int           `json:"parallel,omitempty"`
+	FilesPercent        *int           `json:"filesPercent,omitempty"`
+	MaxErrors           *int           `json:"maxErrors,omitempty"`
+	MaxRetries          *int           `json:"maxRetries,omitempty"`
+	RetryInitialBackoff *time.Duration `json:"retryInitialBackoff,omitempty"`
+	RetryMaxBackoff     *time.Duration `json:"retryMaxBackoff,omitempty"`
+
+	// Schedule, when set, controls how often `kopia object verify apply-policy` re-verifies
+	// this source.
+	Schedule *VerifySchedule `json:"schedule,omitempty"`
+
+	// LastVerified records when this source was last verified by apply-policy, so that
+	// Schedule.Due can tell whether it's due again.
+	LastVerified time.Time `json:"lastVerified,omitempty"`
+
+	// SavedAt records when this VerifyPolicy was written, set by Manager.SetVerifyPolicy.
+	// Since a source can have more than one policy manifest on record (each SetVerifyPolicy
+	// call adds a new one to the append-only manifest store rather than replacing the old
+	// one), Manager.GetVerifyPolicy uses SavedAt to pick the most recent rather than
+	// assuming the store returns manifests in write order.
+	SavedAt time.Time `json:"savedAt,omitempty"`
+}
+
+// VerifySchedule describes how often a source should be re-verified.
+type VerifySchedule struct {
+	Interval time.Duration `json:"interval"`
+}
+
+// Due reports whether a source last verified at lastVerified is due for another
+// verification pass, relative to now. A nil or zero-interval schedule is never due.
+func (s *VerifySchedule) Due(lastVerified, now time.Time) bool {
+	if s == nil || s.Interval <= 0 {
+		return false
+	}
+
+	return lastVerified.IsZero() || now.Sub(lastVerified) >= s.Interval
+}
@@ -0,0 +1,49 @@
+package verify
+
+import "sync"
+
+// ErrorSink collects errors encountered during a parallel run and enforces a maximum-errors
+// threshold. It's shared by any tool that walks many independent items concurrently
+// (objects, directories, raw blocks) and needs to decide when to stop.
+type ErrorSink struct {
+	// Threshold is the number of errors at which TooMany starts reporting true. Zero means
+	// unlimited.
+	Threshold int
+
+	mu     sync.Mutex
+	errors []error
+}
+
+// Add records err and reports whether the configured threshold has now been reached.
+func (s *ErrorSink) Add(err error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errors = append(s.errors, err)
+
+	return s.Threshold > 0 && len(s.errors) >= s.Threshold
+}
+
+// TooMany reports whether the configured threshold has already been reached.
+func (s *ErrorSink) TooMany() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.Threshold > 0 && len(s.errors) >= s.Threshold
+}
+
+// Errors returns a copy of the errors recorded so far.
+func (s *ErrorSink) Errors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]error(nil), s.errors...)
+}
+
+// Seed replaces the recorded errors with errs, e.g. when resuming from a checkpoint.
+func (s *ErrorSink) Seed(errs []error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errors = append([]error(nil), errs...)
+}
@@ -0,0 +1,54 @@
+package verify
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrorSink_NoThresholdNeverTooMany(t *testing.T) {
+	s := &ErrorSink{}
+
+	for i := 0; i < 5; i++ {
+		if s.Add(errors.New("boom")) {
+			t.Fatalf("Add reported too-many with no threshold set")
+		}
+	}
+
+	if s.TooMany() {
+		t.Fatalf("TooMany reported true with no threshold set")
+	}
+
+	if got := len(s.Errors()); got != 5 {
+		t.Fatalf("got %v errors, want 5", got)
+	}
+}
+
+func TestErrorSink_ThresholdReached(t *testing.T) {
+	s := &ErrorSink{Threshold: 2}
+
+	if s.Add(errors.New("one")) {
+		t.Fatalf("expected threshold not reached after first error")
+	}
+
+	if !s.Add(errors.New("two")) {
+		t.Fatalf("expected threshold reached after second error")
+	}
+
+	if !s.TooMany() {
+		t.Fatalf("expected TooMany to report true once threshold is reached")
+	}
+}
+
+func TestErrorSink_Seed(t *testing.T) {
+	s := &ErrorSink{Threshold: 1}
+
+	s.Seed([]error{errors.New("a"), errors.New("b")})
+
+	if got := len(s.Errors()); got != 2 {
+		t.Fatalf("got %v errors after Seed, want 2", got)
+	}
+
+	if !s.TooMany() {
+		t.Fatalf("expected TooMany to report true after seeding past the threshold")
+	}
+}
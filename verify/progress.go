@@ -0,0 +1,41 @@
+package verify
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// ProgressReporter prints periodic progress lines with an ETA, suitable for use as a
+// parallelwork.Queue.ProgressCallback. It's shared by any tool driven by a Queue (the
+// snapshot-walk verifier, the block scrubber, ...).
+type ProgressReporter struct {
+	// Noun describes what's being counted, e.g. "objects" or "blocks".
+	Noun string
+	// TimeFormat is used to render the predicted completion time; defaults to time.RFC3339.
+	TimeFormat string
+	StartTime  time.Time
+}
+
+// Callback is a parallelwork.Queue.ProgressCallback.
+func (p *ProgressReporter) Callback(enqueued, active, completed int64) {
+	timeFormat := p.TimeFormat
+	if timeFormat == "" {
+		timeFormat = time.RFC3339
+	}
+
+	elapsed := time.Since(p.StartTime)
+	maybeTimeRemaining := ""
+
+	if elapsed > time.Second && enqueued > 0 && completed > 0 {
+		completedRatio := float64(completed) / float64(enqueued)
+		predictedSeconds := elapsed.Seconds() / completedRatio
+		predictedEndTime := p.StartTime.Add(time.Duration(predictedSeconds) * time.Second)
+
+		if dt := time.Until(predictedEndTime); dt > 0 {
+			maybeTimeRemaining = fmt.Sprintf(" remaining %v (ETA %v)", dt.Truncate(time.Second), predictedEndTime.Truncate(time.Second).Format(timeFormat))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Found %v %v, processing %v, completed %v%v.\n", enqueued, p.Noun, active, completed, maybeTimeRemaining)
+}
@@ -0,0 +1,167 @@
+package verify
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// ObjectResult describes the outcome of verifying a single object.
+type ObjectResult struct {
+	ObjectID       string `json:"objectID"`
+	Path           string `json:"path,omitempty"`
+	Source         string `json:"source,omitempty"`
+	ExpectedLength int64  `json:"expectedLength,omitempty"`
+	ActualLength   int64  `json:"actualLength,omitempty"`
+	ContentHash    string `json:"contentHash,omitempty"`
+	ErrorClass     string `json:"errorClass,omitempty"`
+	Error          string `json:"error,omitempty"`
+	Retries        int    `json:"retries,omitempty"`
+}
+
+// Summary aggregates the counts and timing of a verify run.
+type Summary struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime,omitempty"`
+	Verified  int64     `json:"verified"`
+	Skipped   int64     `json:"skipped"`
+	Retried   int64     `json:"retried"`
+	Failed    int64     `json:"failed"`
+	BytesRead int64     `json:"bytesRead"`
+}
+
+// Report is the complete machine-readable description of a verify run. It's compact
+// enough to be written once at the end of a run and later fed back in (`--from-report`)
+// to re-verify only the objects that previously failed, or to drive a repair tool.
+type Report struct {
+	Summary Summary        `json:"summary"`
+	Failed  []ObjectResult `json:"failed,omitempty"`
+}
+
+// ReportFormat selects how a Report is persisted to the --report-file.
+type ReportFormat string
+
+// Supported report formats.
+const (
+	ReportFormatJSON   ReportFormat = "json"
+	ReportFormatNDJSON ReportFormat = "ndjson"
+)
+
+// ReportWriter accumulates the results of a verify run and optionally streams each result
+// to an underlying writer as NDJSON (one JSON object per line) so the run can be tailed.
+type ReportWriter struct {
+	w      io.Writer
+	format ReportFormat
+	enc    *json.Encoder
+
+	mu      sync.Mutex
+	summary Summary
+	failed  []ObjectResult
+}
+
+// NewReportWriter creates a ReportWriter. When format is ReportFormatNDJSON, w receives one
+// encoded ObjectResult per call to Record; otherwise results are only accumulated in memory
+// and must be retrieved with Finish.
+func NewReportWriter(w io.Writer, format ReportFormat) *ReportWriter {
+	rw := &ReportWriter{
+		w:       w,
+		format:  format,
+		summary: Summary{StartTime: time.Now()},
+	}
+
+	if w != nil && format == ReportFormatNDJSON {
+		rw.enc = json.NewEncoder(w)
+	}
+
+	return rw
+}
+
+// Record registers the outcome of verifying a single object.
+func (rw *ReportWriter) Record(res ObjectResult) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	rw.summary.BytesRead += res.ActualLength
+	if res.Retries > 0 {
+		rw.summary.Retried++
+	}
+
+	if res.Error != "" {
+		rw.summary.Failed++
+		rw.failed = append(rw.failed, res)
+	} else {
+		rw.summary.Verified++
+	}
+
+	if rw.enc != nil {
+		// best-effort: a failure to stream the report must not abort verification.
+		_ = rw.enc.Encode(res)
+	}
+}
+
+// RecordSkipped registers n objects that were skipped without being verified, e.g. because
+// the error threshold was reached or the run was cancelled.
+func (rw *ReportWriter) RecordSkipped(n int64) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	rw.summary.Skipped += n
+}
+
+// Finish finalizes and returns the accumulated Report. If format is ReportFormatJSON, the
+// caller is expected to marshal the result to the --report-file; for ReportFormatNDJSON the
+// per-object results have already been streamed and this just returns the summary/failures.
+func (rw *ReportWriter) Finish() Report {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	rw.summary.EndTime = time.Now()
+
+	return Report{
+		Summary: rw.summary,
+		Failed:  rw.failed,
+	}
+}
+
+// WriteManifest writes the compact JSON manifest for rep to w. It's the format consumed by
+// `kopia object verify --from-report` and, eventually, a repair command.
+func WriteManifest(w io.Writer, rep Report) error {
+	enc := json.NewEncoder(w)
+	return enc.Encode(rep)
+}
+
+// ReadManifest reads the Report previously written by WriteManifest. For a
+// ReportFormatNDJSON report file, the per-object ObjectResults recorded during the run
+// precede the final manifest as their own JSON values on the stream; ReadManifest decodes
+// every value in turn and keeps the last one, which is always the manifest WriteManifest
+// appended when the run finished (an ObjectResult decoded as a Report yields a harmless
+// zero value, since their fields don't overlap).
+func ReadManifest(r io.Reader) (Report, error) {
+	dec := json.NewDecoder(r)
+
+	var (
+		rep   Report
+		found bool
+	)
+
+	for {
+		var next Report
+		if err := dec.Decode(&next); err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			return Report{}, err
+		}
+
+		rep = next
+		found = true
+	}
+
+	if !found {
+		return Report{}, io.ErrUnexpectedEOF
+	}
+
+	return rep, nil
+}
@@ -0,0 +1,66 @@
+package verify
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestReportWriter_JSONRoundTrip(t *testing.T) {
+	rw := NewReportWriter(nil, ReportFormatJSON)
+	rw.Record(ObjectResult{ObjectID: "ok", ActualLength: 10})
+	rw.Record(ObjectResult{ObjectID: "bad", Error: "checksum mismatch"})
+
+	var buf bytes.Buffer
+	if err := WriteManifest(&buf, rw.Finish()); err != nil {
+		t.Fatalf("WriteManifest: %v", err)
+	}
+
+	got, err := ReadManifest(&buf)
+	if err != nil {
+		t.Fatalf("ReadManifest: %v", err)
+	}
+
+	if got.Summary.Verified != 1 || got.Summary.Failed != 1 {
+		t.Fatalf("got summary %+v, want 1 verified, 1 failed", got.Summary)
+	}
+
+	if len(got.Failed) != 1 || got.Failed[0].ObjectID != "bad" {
+		t.Fatalf("got failed %+v, want [bad]", got.Failed)
+	}
+}
+
+func TestReportWriter_NDJSONRoundTrip(t *testing.T) {
+	// NDJSON streams each ObjectResult to the file as it's recorded; the CLI appends the
+	// final Report manifest to the same file once the run finishes. ReadManifest must
+	// still recover the correct summary/failed list, not the first (ObjectResult-shaped)
+	// value on the stream.
+	var buf bytes.Buffer
+
+	rw := NewReportWriter(&buf, ReportFormatNDJSON)
+	rw.Record(ObjectResult{ObjectID: "ok", ActualLength: 10})
+	rw.Record(ObjectResult{ObjectID: "bad", Error: "checksum mismatch"})
+
+	report := rw.Finish()
+	if err := WriteManifest(&buf, report); err != nil {
+		t.Fatalf("WriteManifest: %v", err)
+	}
+
+	got, err := ReadManifest(&buf)
+	if err != nil {
+		t.Fatalf("ReadManifest: %v", err)
+	}
+
+	if got.Summary.Verified != 1 || got.Summary.Failed != 1 {
+		t.Fatalf("got summary %+v, want 1 verified, 1 failed", got.Summary)
+	}
+
+	if len(got.Failed) != 1 || got.Failed[0].ObjectID != "bad" {
+		t.Fatalf("got failed %+v, want [bad]", got.Failed)
+	}
+}
+
+func TestReadManifest_EmptyStreamIsAnError(t *testing.T) {
+	if _, err := ReadManifest(&bytes.Buffer{}); err == nil {
+		t.Fatalf("expected an error reading an empty report file")
+	}
+}
@@ -0,0 +1,98 @@
+// Package verify provides the core work-classification and retry primitives shared by
+// object verification tools (the `kopia object verify` CLI command and, eventually,
+// server-side or repair-oriented verifiers).
+package verify
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// ErrorClass categorizes an error encountered while verifying an object so that callers
+// can decide whether it's worth retrying.
+type ErrorClass int
+
+// Error classes recognized by ClassifyError.
+const (
+	// ErrorClassPermanent indicates an error that will not go away on retry, such as a
+	// checksum mismatch or missing block metadata.
+	ErrorClassPermanent ErrorClass = iota
+	// ErrorClassTransient indicates an error that may succeed if retried, such as a
+	// network timeout or a temporary storage error.
+	ErrorClassTransient
+)
+
+// TemporaryError can be implemented by errors that know whether they're transient.
+type TemporaryError interface {
+	Temporary() bool
+}
+
+// ClassifyError decides whether err is transient (worth retrying) or permanent.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassPermanent
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTransient
+	}
+
+	var te TemporaryError
+	if errors.As(err, &te) && te.Temporary() {
+		return ErrorClassTransient
+	}
+
+	if to, ok := err.(interface{ Timeout() bool }); ok && to.Timeout() {
+		return ErrorClassTransient
+	}
+
+	return ErrorClassPermanent
+}
+
+// RetryPolicy controls the exponential backoff applied to transient errors.
+type RetryPolicy struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// backoff returns the delay before retry number 'attempt' (0-based), with full jitter
+// applied so that concurrent workers don't retry in lockstep.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.InitialBackoff << uint(attempt)
+	if d <= 0 || d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// Do invokes fn, retrying according to p whenever fn returns a transient error, until it
+// succeeds, a permanent error is returned, retries are exhausted, or ctx is cancelled. It
+// returns the number of retries actually performed together with fn's final error.
+func (p RetryPolicy) Do(ctx context.Context, fn func() error) (retries int, err error) {
+	for {
+		err = fn()
+		if err == nil {
+			return retries, nil
+		}
+
+		if ctx.Err() != nil {
+			return retries, ctx.Err()
+		}
+
+		if ClassifyError(err) != ErrorClassTransient || retries >= p.MaxRetries {
+			return retries, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return retries, ctx.Err()
+		case <-time.After(p.backoff(retries)):
+		}
+
+		retries++
+	}
+}
@@ -0,0 +1,104 @@
+package verify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type temporaryError struct{ temporary bool }
+
+func (e temporaryError) Error() string   { return "temporary error" }
+func (e temporaryError) Temporary() bool { return e.temporary }
+
+func TestClassifyError(t *testing.T) {
+	if got := ClassifyError(nil); got != ErrorClassPermanent {
+		t.Fatalf("got %v, want ErrorClassPermanent for nil", got)
+	}
+
+	if got := ClassifyError(context.DeadlineExceeded); got != ErrorClassTransient {
+		t.Fatalf("got %v, want ErrorClassTransient for DeadlineExceeded", got)
+	}
+
+	if got := ClassifyError(temporaryError{temporary: true}); got != ErrorClassTransient {
+		t.Fatalf("got %v, want ErrorClassTransient for a temporary error", got)
+	}
+
+	if got := ClassifyError(errors.New("boom")); got != ErrorClassPermanent {
+		t.Fatalf("got %v, want ErrorClassPermanent for a plain error", got)
+	}
+}
+
+func TestRetryPolicy_DoSucceedsWithoutRetry(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	retries, err := p.Do(context.Background(), func() error { return nil })
+	if err != nil || retries != 0 {
+		t.Fatalf("got (%v, %v), want (0, nil)", retries, err)
+	}
+}
+
+func TestRetryPolicy_DoRetriesTransientUntilSuccess(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 5, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	attempts := 0
+	retries, err := p.Do(context.Background(), func() error {
+		attempts++
+		if attempts < 3 {
+			return temporaryError{temporary: true}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if retries != 2 {
+		t.Fatalf("got %v retries, want 2", retries)
+	}
+}
+
+func TestRetryPolicy_DoGivesUpOnPermanentError(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 5, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	wantErr := errors.New("permanent")
+	attempts := 0
+	retries, err := p.Do(context.Background(), func() error {
+		attempts++
+		return wantErr
+	})
+	if err != wantErr {
+		t.Fatalf("got err %v, want %v", err, wantErr)
+	}
+	if retries != 0 || attempts != 1 {
+		t.Fatalf("got (retries=%v, attempts=%v), want (0, 1)", retries, attempts)
+	}
+}
+
+func TestRetryPolicy_DoStopsAtMaxRetries(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	attempts := 0
+	retries, err := p.Do(context.Background(), func() error {
+		attempts++
+		return temporaryError{temporary: true}
+	})
+	if err == nil {
+		t.Fatalf("expected an error once retries are exhausted")
+	}
+	if retries != 2 || attempts != 3 {
+		t.Fatalf("got (retries=%v, attempts=%v), want (2, 3)", retries, attempts)
+	}
+}
+
+func TestRetryPolicy_DoStopsOnCancellation(t *testing.T) {
+	p := RetryPolicy{MaxRetries: 5, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := p.Do(ctx, func() error { return temporaryError{temporary: true} })
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got err %v, want context.Canceled", err)
+	}
+}